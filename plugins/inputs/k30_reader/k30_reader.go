@@ -1,35 +1,29 @@
 package k30_reader
 
 import (
-	"bytes"
 	"context"
 	"encoding/binary"
-	"encoding/hex"
-	"github.com/influxdata/telegraf"
-	"github.com/influxdata/telegraf/plugins/inputs"
+	"fmt"
 	"math"
-	"os"
-	"os/exec"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
-)
 
-// default values
-const (
-	GATTTOOL  = "/usr/bin/gatttool"
-	MACADDR   = "C1:C4:E4:05:14:95"
-	VARHANDLE = "0x000e"
-	GATTFLAGS = "-t random --char-read"
+	"github.com/go-ble/ble"
+	"github.com/go-ble/ble/linux"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
-// env variable names
+// default values
 const (
-	GATT_TOOL  = "GATTTOOL"
-	MAC_ADDR   = "MACADDR"
-	VAR_HANDLE = "VAR_HANDLE"
-	GATT_FLAGS = "GATTFLAGS"
+	MACADDR            = "C1:C4:E4:05:14:95"
+	CHARACTERISTICUUID = "0000000e00001000800000805f9b34fb"
+	ADAPTER            = "hci0"
+	CONNECTTIMEOUT     = 10 * time.Second
+	RECONNECTINTERVAL  = 5 * time.Second
 )
 
 type Wireless struct {
@@ -38,30 +32,69 @@ type Wireless struct {
 }
 
 var sampleConfig = `
-  ## command  for reading. If empty default path will be used:
-  ##    gatttool -b C1:C4:E4:05:14:95 -t random --char-read  --handle=0x000e
-  ## This can also be overridden with env variable, see README.
-  gatttool = "/usr/bin/gatttool"
-  macaddr = "C1:C4:E4:05:14:95"
-  varhandle = "0x000e"
-  gattflags = "-t random --char-read"
-  ## dump metrics with 0 values too
-  dump_zeros       = true
+  ## BLE adapter to use when a device below doesn't override it, e.g. hci0
+  adapter = "hci0"
+  ## how long to wait for a connection before giving up
+  connect_timeout = "10s"
+  ## how long to wait before attempting to reconnect after a dropped
+  ## connection
+  reconnect_interval = "5s"
+
+  ## one [[inputs.k30_reader.device]] table per K30 sensor to scrape
+  [[inputs.k30_reader.device]]
+    ## MAC address of the K30 BLE peripheral
+    mac = "C1:C4:E4:05:14:95"
+    ## GATT characteristic UUID that exposes the CO2 reading as a
+    ## little-endian 4-byte float
+    characteristic_uuid = "0000000e00001000800000805f9b34fb"
+    ## friendly name used for the sensor_name tag
+    name = "k30_co2"
+    ## optional per-device adapter override
+    # adapter = "hci0"
 `
 
+// Device is a single K30 sensor to scrape over BLE.
+type Device struct {
+	Mac                string `toml:"mac"`
+	CharacteristicUUID string `toml:"characteristic_uuid"`
+	Name               string `toml:"name"`
+	Adapter            string `toml:"adapter"`
+
+	mu          sync.Mutex
+	client      ble.Client
+	char        *ble.Characteristic
+	lastFailure time.Time
+
+	// readFunc, when set, substitutes the real BLE dial/read path. Tests in
+	// this package use it to exercise Gather's per-device error isolation
+	// without touching real hardware.
+	readFunc func() ([]byte, error)
+}
+
+// K30 collects CO2 readings from one or more K30 sensors exposed over
+// Bluetooth Low Energy. Each configured device keeps a persistent GATT
+// connection open and reconnects automatically if the peripheral drops
+// off. Devices that share an adapter share the single HCI device opened
+// for it, since dialing concurrently is only safe through one owner per
+// controller.
 type K30 struct {
-	CMD       string `toml:"gatttool"`
-	ADDR      string `toml:"macaddr"`
-	HANDLE    string `toml:"varhandle"`
-	FLAGS     string `toml:"gattflags"`
-	DumpZeros bool   `toml:"dump_zeros"`
+	Adapter           string        `toml:"adapter"`
+	ConnectTimeout    time.Duration `toml:"connect_timeout"`
+	ReconnectInterval time.Duration `toml:"reconnect_interval"`
+	Devices           []*Device     `toml:"device"`
+
+	adaptersMu sync.Mutex
+	adapters   map[string]*adapterEntry
 }
 
-var (
-	colonByte = []byte(":")
-	spaceByte = []byte(" ")
-	emptyByte = []byte("")
-)
+// adapterEntry pairs a shared ble.Device with the mutex that serializes
+// Dial calls against it. The HCI stack only tolerates one in-flight
+// connection attempt per controller, so every device sharing an adapter
+// must queue through dialMu rather than calling Dial concurrently.
+type adapterEntry struct {
+	dev    ble.Device
+	dialMu sync.Mutex
+}
 
 func Float32frombytes(bytes []byte) float32 {
 	bits := binary.LittleEndian.Uint32(bytes)
@@ -75,6 +108,7 @@ func Float32bytes(float float32) []byte {
 	binary.LittleEndian.PutUint32(bytes, bits)
 	return bytes
 }
+
 func (ns *K30) Description() string {
 	return "Collect CO2 Readings via Bluetooth from a K30-enabled Arduino"
 }
@@ -82,87 +116,214 @@ func (ns *K30) Description() string {
 func (ns *K30) SampleConfig() string {
 	return sampleConfig
 }
-func exe_cmd(cmd string, wg *sync.WaitGroup) ([]byte, error) {
-	parts := strings.Fields(cmd)
-	head := parts[0]
-	parts = parts[1:len(parts)]
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel() // The cancel should be deferred so resources are cleaned up
-
-	// Create the command with our context
-	out, err := exec.CommandContext(ctx, head, parts...).Output()
-
-	// We want to check the context error to see if the timeout was executed.
-	// The error returned by cmd.Output() will be OS specific based on what
-	// happens when a process is killed.
-	if ctx.Err() == context.DeadlineExceeded {
-		wg.Done()
+
+func (ns *K30) loadDefaults() {
+	if ns.Adapter == "" {
+		ns.Adapter = ADAPTER
+	}
+	if ns.ConnectTimeout == 0 {
+		ns.ConnectTimeout = CONNECTTIMEOUT
+	}
+	if ns.ReconnectInterval == 0 {
+		ns.ReconnectInterval = RECONNECTINTERVAL
+	}
+	if len(ns.Devices) == 0 {
+		ns.Devices = []*Device{{Mac: MACADDR, CharacteristicUUID: CHARACTERISTICUUID, Name: "k30_co2"}}
+	}
+	for _, d := range ns.Devices {
+		if d.Adapter == "" {
+			d.Adapter = ns.Adapter
+		}
+		if d.Name == "" {
+			d.Name = d.Mac
+		}
+	}
+}
+
+// parseAdapterID turns an "hciN" adapter name into the HCI controller index
+// expected by ble.OptDeviceID.
+func parseAdapterID(name string) (int, error) {
+	id, err := strconv.Atoi(strings.TrimPrefix(name, "hci"))
+	if err != nil {
+		return 0, fmt.Errorf("adapter %q is not a valid hciN device: %w", name, err)
+	}
+	return id, nil
+}
+
+// adapterDevice returns the shared adapterEntry for the named HCI adapter,
+// opening the underlying ble.Device on first use. Devices are opened once
+// and reused for the life of the plugin so concurrent Gather calls dial
+// through a single owner per controller instead of racing on process-global
+// state.
+func (ns *K30) adapterDevice(name string) (*adapterEntry, error) {
+	ns.adaptersMu.Lock()
+	defer ns.adaptersMu.Unlock()
+
+	if entry, ok := ns.adapters[name]; ok {
+		return entry, nil
+	}
+
+	id, err := parseAdapterID(name)
+	if err != nil {
 		return nil, err
 	}
-	wg.Done() // Need to signal to waitgroup that this goroutine is done
-	return out, err
+	dev, err := linux.NewDevice(ble.OptDeviceID(id))
+	if err != nil {
+		return nil, fmt.Errorf("opening adapter %s: %w", name, err)
+	}
+
+	entry := &adapterEntry{dev: dev}
+	if ns.adapters == nil {
+		ns.adapters = map[string]*adapterEntry{}
+	}
+	ns.adapters[name] = entry
+	return entry, nil
 }
 
-func (ns *K30) Gather(acc telegraf.Accumulator) error {
-	ns.loadPath()
-	wg := new(sync.WaitGroup)
-	wg.Add(1)
-	built_cmd := ns.CMD + " -b " + ns.ADDR + " " + ns.FLAGS + " --handle=" + ns.HANDLE
-	k30, err := exe_cmd(built_cmd, wg)
+// connect dials the device's configured peripheral directly on the given
+// adapter and resolves the configured characteristic. It is safe to call
+// repeatedly; a prior connection is closed first. Dialing and profile
+// discovery are serialized per adapter via entry.dialMu, since concurrent
+// Dial calls on the same HCI controller race.
+func (d *Device) connect(entry *adapterEntry, timeout time.Duration) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.client != nil {
+		d.client.ClearSubscriptions()
+		d.client.CancelConnection()
+		d.client = nil
+		d.char = nil
+	}
+
+	entry.dialMu.Lock()
+	defer entry.dialMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	client, err := entry.dev.Dial(ctx, ble.NewAddr(d.Mac))
 	if err != nil {
-		return err
+		return fmt.Errorf("connecting to %s: %w", d.Mac, err)
 	}
-	err = ns.gatherk30(k30, acc)
+
+	profile, err := client.DiscoverProfile(true)
 	if err != nil {
-		return err
+		client.CancelConnection()
+		return fmt.Errorf("discovering profile on %s: %w", d.Mac, err)
 	}
+
+	uuid, err := ble.Parse(d.CharacteristicUUID)
+	if err != nil {
+		client.CancelConnection()
+		return fmt.Errorf("parsing characteristic uuid %q: %w", d.CharacteristicUUID, err)
+	}
+	char := profile.Find(ble.NewCharacteristic(uuid))
+	if char == nil {
+		client.CancelConnection()
+		return fmt.Errorf("characteristic %s not found on %s", d.CharacteristicUUID, d.Mac)
+	}
+
+	d.client = client
+	d.char = char.(*ble.Characteristic)
 	return nil
 }
 
-func (ns *K30) gatherk30(data []byte, acc telegraf.Accumulator) error {
-	tags := map[string]string{}
-	metrics := map[string]interface{}{}
-	tags["sensor"] = "k30_co2"
-	result := bytes.Split(data, colonByte)
-	fd := bytes.Fields(result[1])
-	reading := make([]byte, 4)
-	for x := 0; x < len(fd); x++ {
-		data, err := hex.DecodeString(string(fd[x]))
-		if err != nil {
-			panic(err)
-		}
-		reading[x] = data[0]
+// ensureConnected reconnects the device if it isn't already connected,
+// unless a read on its previous connection failed less than
+// reconnectInterval ago: it then returns an error immediately rather than
+// dialing again. A failed dial is not recorded, so the next call after one
+// dials again at once.
+func (d *Device) ensureConnected(entry *adapterEntry, timeout, reconnectInterval time.Duration) error {
+	d.mu.Lock()
+	connected := d.client != nil
+	wait := reconnectInterval - time.Since(d.lastFailure)
+	d.mu.Unlock()
+	if connected {
+		return nil
 	}
-	float := Float32frombytes(reading)
-	metrics["co2"] = float
-	acc.AddFields("k30_reader", metrics, tags)
-	return nil
+	if wait > 0 {
+		return fmt.Errorf("waiting %s before reconnecting to %s", wait.Round(time.Millisecond), d.Mac)
+	}
+	return d.connect(entry, timeout)
 }
 
-// loadPath can be used to read path firstly from config
-// if it is empty then try read from env variables
-func (ns *K30) loadPath() {
-	if ns.CMD == "" {
-		ns.CMD = proc(GATT_TOOL, "")
+// read returns the raw characteristic value for this device, reconnecting
+// first if necessary.
+func (d *Device) read(entry *adapterEntry, timeout, reconnectInterval time.Duration) ([]byte, error) {
+	if err := d.ensureConnected(entry, timeout, reconnectInterval); err != nil {
+		return nil, err
 	}
-	if ns.ADDR == "" {
-		ns.ADDR = proc(MAC_ADDR, "")
+
+	d.mu.Lock()
+	client, char := d.client, d.char
+	d.mu.Unlock()
+
+	reading, err := client.ReadCharacteristic(char)
+	if err != nil {
+		// the peripheral likely dropped the connection; clear the cached
+		// client and record the failure time so ensureConnected waits out
+		// reconnectInterval on the next Gather instead of dialing again
+		// immediately
+		d.mu.Lock()
+		d.client = nil
+		d.char = nil
+		d.lastFailure = time.Now()
+		d.mu.Unlock()
+		return nil, fmt.Errorf("reading characteristic from %s: %w", d.Mac, err)
 	}
-	if ns.HANDLE == "" {
-		ns.HANDLE = proc(VAR_HANDLE, "")
+	return reading, nil
+}
+
+func (ns *K30) Gather(acc telegraf.Accumulator) error {
+	ns.loadDefaults()
+
+	var wg sync.WaitGroup
+	for _, d := range ns.Devices {
+		wg.Add(1)
+		go func(d *Device) {
+			defer wg.Done()
+			reading, err := ns.fetch(d)
+			if err != nil {
+				acc.AddError(err)
+				return
+			}
+			if err := ns.gatherk30(d, reading, acc); err != nil {
+				acc.AddError(err)
+			}
+		}(d)
+	}
+	wg.Wait()
+
+	return nil
+}
+
+// fetch returns the raw characteristic bytes for d, either via its real BLE
+// connection or, in tests, via d.readFunc.
+func (ns *K30) fetch(d *Device) ([]byte, error) {
+	if d.readFunc != nil {
+		return d.readFunc()
 	}
-	if ns.FLAGS == "" {
-		ns.FLAGS = proc(GATT_FLAGS, "")
+	entry, err := ns.adapterDevice(d.Adapter)
+	if err != nil {
+		return nil, err
 	}
+	return d.read(entry, ns.ConnectTimeout, ns.ReconnectInterval)
 }
 
-// proc can be used to read file paths from env
-func proc(env, path string) string {
-	// try to read full file path
-	if p := os.Getenv(env); p != "" {
-		return p
+func (ns *K30) gatherk30(d *Device, data []byte, acc telegraf.Accumulator) error {
+	if len(data) < 4 {
+		return fmt.Errorf("short read from k30 %s: got %d bytes, want 4", d.Mac, len(data))
 	}
-	return env
+	tags := map[string]string{
+		"sensor_name": d.Name,
+		"mac":         d.Mac,
+	}
+	metrics := map[string]interface{}{
+		"co2": Float32frombytes(data[:4]),
+	}
+	acc.AddFields("k30_reader", metrics, tags)
+	return nil
 }
 
 func init() {
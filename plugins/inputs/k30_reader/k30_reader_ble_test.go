@@ -0,0 +1,190 @@
+package k30_reader
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-ble/ble"
+)
+
+// concurrencyGuard records the maximum number of goroutines that were ever
+// inside an enter/leave section at the same time, so a test can assert that
+// two calls never overlapped.
+type concurrencyGuard struct {
+	mu      sync.Mutex
+	active  int
+	maxSeen int
+}
+
+func (g *concurrencyGuard) enter() {
+	g.mu.Lock()
+	g.active++
+	if g.active > g.maxSeen {
+		g.maxSeen = g.active
+	}
+	g.mu.Unlock()
+}
+
+func (g *concurrencyGuard) leave() {
+	g.mu.Lock()
+	g.active--
+	g.mu.Unlock()
+}
+
+// fakeDevice stands in for the real ble.Device opened by adapterDevice. It
+// embeds the interface so any method besides Dial panics if exercised,
+// which is fine since connect/read never call anything else on it.
+type fakeDevice struct {
+	ble.Device
+
+	guard     *concurrencyGuard
+	newClient func(mac string) ble.Client
+}
+
+func (f *fakeDevice) Dial(_ context.Context, a ble.Addr) (ble.Client, error) {
+	if f.guard != nil {
+		f.guard.enter()
+		defer f.guard.leave()
+	}
+	// give a concurrent Dial a window to race in if dialMu isn't held
+	time.Sleep(5 * time.Millisecond)
+	return f.newClient(a.String()), nil
+}
+
+// fakeClient stands in for the ble.Client returned by Dial. It embeds the
+// interface so only the methods connect/read actually call need real
+// implementations.
+type fakeClient struct {
+	ble.Client
+
+	guard   *concurrencyGuard
+	profile *ble.Profile
+	reads   func() ([]byte, error)
+}
+
+func (c *fakeClient) DiscoverProfile(bool) (*ble.Profile, error) {
+	if c.guard != nil {
+		c.guard.enter()
+		defer c.guard.leave()
+	}
+	time.Sleep(5 * time.Millisecond)
+	return c.profile, nil
+}
+
+func (c *fakeClient) ReadCharacteristic(*ble.Characteristic) ([]byte, error) {
+	return c.reads()
+}
+
+func (c *fakeClient) ClearSubscriptions() error { return nil }
+func (c *fakeClient) CancelConnection() error   { return nil }
+
+// fakeProfile builds a minimal profile exposing a single characteristic
+// with the given UUID, enough for Device.connect's profile.Find to resolve it.
+func fakeProfile(uuid ble.UUID) *ble.Profile {
+	return &ble.Profile{
+		Services: []*ble.Service{
+			{
+				UUID:            uuid,
+				Characteristics: []*ble.Characteristic{{UUID: uuid}},
+			},
+		},
+	}
+}
+
+// TestConnectSerializesPerAdapter exercises two devices sharing a single
+// adapterEntry, exactly as Gather does when two [[device]] tables share an
+// adapter, and checks that entry.dialMu actually keeps their Dial and
+// DiscoverProfile calls from ever running concurrently.
+func TestConnectSerializesPerAdapter(t *testing.T) {
+	uuid, err := ble.Parse(CHARACTERISTICUUID)
+	if err != nil {
+		t.Fatalf("parsing test uuid: %v", err)
+	}
+	profile := fakeProfile(uuid)
+
+	guard := &concurrencyGuard{}
+	dev := &fakeDevice{
+		guard: guard,
+		newClient: func(string) ble.Client {
+			return &fakeClient{guard: guard, profile: profile}
+		},
+	}
+	entry := &adapterEntry{dev: dev}
+
+	devices := []*Device{
+		{Mac: "AA:AA:AA:AA:AA:AA", CharacteristicUUID: CHARACTERISTICUUID, Name: "one"},
+		{Mac: "BB:BB:BB:BB:BB:BB", CharacteristicUUID: CHARACTERISTICUUID, Name: "two"},
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(devices))
+	for _, d := range devices {
+		wg.Add(1)
+		go func(d *Device) {
+			defer wg.Done()
+			errs <- d.connect(entry, time.Second)
+		}(d)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("connect returned error: %v", err)
+		}
+	}
+	if guard.maxSeen > 1 {
+		t.Fatalf("Dial/DiscoverProfile ran concurrently across devices sharing an adapter: maxSeen=%d", guard.maxSeen)
+	}
+}
+
+// TestReadReconnectsAfterDroppedConnection checks that a failed
+// ReadCharacteristic drops the cached client and that the next read dials a
+// fresh connection rather than reusing the stale one.
+func TestReadReconnectsAfterDroppedConnection(t *testing.T) {
+	uuid, err := ble.Parse(CHARACTERISTICUUID)
+	if err != nil {
+		t.Fatalf("parsing test uuid: %v", err)
+	}
+	profile := fakeProfile(uuid)
+
+	var dialCount, readCount int32
+	dev := &fakeDevice{
+		newClient: func(string) ble.Client {
+			atomic.AddInt32(&dialCount, 1)
+			return &fakeClient{
+				profile: profile,
+				reads: func() ([]byte, error) {
+					if atomic.AddInt32(&readCount, 1) == 1 {
+						return nil, errors.New("simulated disconnect")
+					}
+					return Float32bytes(123.5), nil
+				},
+			}
+		},
+	}
+	entry := &adapterEntry{dev: dev}
+	d := &Device{Mac: "AA:AA:AA:AA:AA:AA", CharacteristicUUID: CHARACTERISTICUUID, Name: "k30"}
+
+	if _, err := d.read(entry, time.Second, 0); err == nil {
+		t.Fatal("expected the first read to surface the simulated disconnect")
+	}
+	if got := atomic.LoadInt32(&dialCount); got != 1 {
+		t.Fatalf("expected 1 dial before the drop, got %d", got)
+	}
+
+	data, err := d.read(entry, time.Second, 0)
+	if err != nil {
+		t.Fatalf("expected the next read to reconnect cleanly, got error: %v", err)
+	}
+	if got := Float32frombytes(data); got != 123.5 {
+		t.Fatalf("got co2 %v, want 123.5", got)
+	}
+	if got := atomic.LoadInt32(&dialCount); got != 2 {
+		t.Fatalf("expected a fresh dial after the dropped connection, got %d", got)
+	}
+}
@@ -0,0 +1,56 @@
+package k30_reader
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestGatherIsolatesPerDeviceErrors(t *testing.T) {
+	good := &Device{
+		Mac:  "AA:AA:AA:AA:AA:AA",
+		Name: "k30_good",
+		readFunc: func() ([]byte, error) {
+			return Float32bytes(512.5), nil
+		},
+	}
+	bad := &Device{
+		Mac:  "BB:BB:BB:BB:BB:BB",
+		Name: "k30_bad",
+		readFunc: func() ([]byte, error) {
+			return nil, errors.New("connecting to BB:BB:BB:BB:BB:BB: no route to device")
+		},
+	}
+
+	ns := &K30{Devices: []*Device{good, bad}}
+	var acc testutil.Accumulator
+	if err := ns.Gather(&acc); err != nil {
+		t.Fatalf("Gather returned error: %v", err)
+	}
+
+	if len(acc.Errors) != 1 {
+		t.Fatalf("expected exactly 1 error from the failing device, got %d: %v", len(acc.Errors), acc.Errors)
+	}
+
+	if n := acc.NMetrics(); n != 1 {
+		t.Fatalf("expected exactly 1 metric from the succeeding device, got %d", n)
+	}
+
+	acc.AssertContainsTaggedFields(t, "k30_reader", map[string]interface{}{
+		"co2": float32(512.5),
+	}, map[string]string{
+		"sensor_name": "k30_good",
+		"mac":         "AA:AA:AA:AA:AA:AA",
+	})
+}
+
+func TestGatherk30ShortRead(t *testing.T) {
+	ns := &K30{}
+	d := &Device{Mac: "AA:AA:AA:AA:AA:AA", Name: "k30_good"}
+	var acc testutil.Accumulator
+
+	if err := ns.gatherk30(d, []byte{0x01, 0x02}, &acc); err == nil {
+		t.Fatal("expected a short-read error, got nil")
+	}
+}
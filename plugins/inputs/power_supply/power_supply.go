@@ -0,0 +1,46 @@
+package power_supply
+
+import (
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+var sampleConfig = `
+  ## Limit collection to specific supplies by name, e.g. BAT0, AC.
+  ## If empty, all supplies under /sys/class/power_supply are collected.
+  # supplies = ["BAT0"]
+`
+
+// PowerSupply collects battery and AC-adapter health metrics. On Linux it
+// reads directly from /sys/class/power_supply; on other platforms it falls
+// back to the distatus/battery library.
+type PowerSupply struct {
+	Supplies []string `toml:"supplies"`
+}
+
+func (ns *PowerSupply) Description() string {
+	return "Collect power supply (battery, AC, USB) health stats"
+}
+
+func (ns *PowerSupply) SampleConfig() string {
+	return sampleConfig
+}
+
+// included reports whether a supply name passes the configured filter.
+func (ns *PowerSupply) included(name string) bool {
+	if len(ns.Supplies) == 0 {
+		return true
+	}
+	for _, s := range ns.Supplies {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	inputs.Add("power_supply", func() telegraf.Input {
+		return &PowerSupply{}
+	})
+}
@@ -0,0 +1,113 @@
+//go:build linux
+
+package power_supply
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+)
+
+// sysfsPowerSupplyPath is a var, not a const, so tests can point it at a
+// fake sysfs tree instead of reading real hardware.
+var sysfsPowerSupplyPath = "/sys/class/power_supply"
+
+// stringAttrs are sysfs attributes that should be collected as-is.
+var stringAttrs = []string{"status", "technology", "manufacturer", "model_name", "health"}
+
+// microAttrs are sysfs attributes reported in micro-units that must be
+// scaled down to their base unit (volts, amps, amp-hours, watt-hours).
+var microAttrs = []string{"voltage_now", "current_now", "charge_now", "energy_now"}
+
+// plainAttrs are sysfs attributes reported in their base unit already.
+var plainAttrs = []string{"capacity", "cycle_count", "present"}
+
+func (ns *PowerSupply) Gather(acc telegraf.Accumulator) error {
+	supplies, err := os.ReadDir(sysfsPowerSupplyPath)
+	if err != nil {
+		return err
+	}
+
+	for _, supply := range supplies {
+		name := supply.Name()
+		if !ns.included(name) {
+			continue
+		}
+		ns.gatherSupply(name, acc)
+	}
+	return nil
+}
+
+// gatherSupply reads every known sysfs attribute for one supply. A
+// malformed individual attribute is reported via acc.AddError and skipped
+// rather than discarding the rest of the supply's otherwise-valid metrics.
+func (ns *PowerSupply) gatherSupply(name string, acc telegraf.Accumulator) {
+	dir := filepath.Join(sysfsPowerSupplyPath, name)
+	fields := map[string]interface{}{}
+
+	supplyType := readSysfsString(filepath.Join(dir, "type"))
+	tags := map[string]string{
+		"name": name,
+		"type": supplyType,
+	}
+
+	for _, attr := range stringAttrs {
+		if v, ok := readAttr(dir, attr); ok {
+			fields[attr] = v
+		}
+	}
+
+	for _, attr := range plainAttrs {
+		v, ok := readAttr(dir, attr)
+		if !ok {
+			continue
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			acc.AddError(fmt.Errorf("parsing %s %s: %w", name, attr, err))
+			continue
+		}
+		fields[attr] = f
+	}
+
+	for _, attr := range microAttrs {
+		v, ok := readAttr(dir, attr)
+		if !ok {
+			continue
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			acc.AddError(fmt.Errorf("parsing %s %s: %w", name, attr, err))
+			continue
+		}
+		// sysfs reports these in micro-units (uV, uA, uAh, uWh)
+		fields[attr] = f / 1e6
+	}
+
+	if len(fields) == 0 {
+		return
+	}
+	acc.AddFields("power_supply", fields, tags)
+}
+
+// readAttr reads a single sysfs attribute file, returning ok=false if the
+// attribute doesn't exist for this supply.
+func readAttr(dir, attr string) (string, bool) {
+	s := readSysfsString(filepath.Join(dir, attr))
+	if s == "" {
+		return "", false
+	}
+	return s, true
+}
+
+func readSysfsString(path string) string {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
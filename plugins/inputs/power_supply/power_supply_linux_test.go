@@ -0,0 +1,109 @@
+//go:build linux
+
+package power_supply
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/influxdata/telegraf/testutil"
+)
+
+// writeSupply creates a fake /sys/class/power_supply/<name>/ directory
+// populated with the given sysfs attribute files.
+func writeSupply(t *testing.T, root, name string, attrs map[string]string) {
+	t.Helper()
+	dir := filepath.Join(root, name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("creating fake supply dir: %v", err)
+	}
+	for attr, v := range attrs {
+		if err := os.WriteFile(filepath.Join(dir, attr), []byte(v), 0o644); err != nil {
+			t.Fatalf("writing fake attr %s: %v", attr, err)
+		}
+	}
+}
+
+func TestGatherSupplyScaling(t *testing.T) {
+	root := t.TempDir()
+	sysfsPowerSupplyPath = root
+	t.Cleanup(func() { sysfsPowerSupplyPath = "/sys/class/power_supply" })
+
+	writeSupply(t, root, "BAT0", map[string]string{
+		"type":        "Battery",
+		"status":      "Discharging",
+		"capacity":    "87",
+		"cycle_count": "42",
+		"present":     "1",
+		"voltage_now": "12582000", // 12.582 V
+		"current_now": "1500000",  // 1.5 A
+		"charge_now":  "2500000",  // 2.5 Ah
+		"energy_now":  "30000000", // 30 Wh
+	})
+
+	ns := &PowerSupply{}
+	var acc testutil.Accumulator
+	ns.gatherSupply("BAT0", &acc)
+
+	acc.AssertContainsTaggedFields(t, "power_supply", map[string]interface{}{
+		"status":      "Discharging",
+		"capacity":    float64(87),
+		"cycle_count": float64(42),
+		"present":     float64(1),
+		"voltage_now": 12.582,
+		"current_now": 1.5,
+		"charge_now":  2.5,
+		"energy_now":  30.0,
+	}, map[string]string{
+		"name": "BAT0",
+		"type": "Battery",
+	})
+}
+
+func TestGatherSupplySkipsMissingAttrs(t *testing.T) {
+	root := t.TempDir()
+	sysfsPowerSupplyPath = root
+	t.Cleanup(func() { sysfsPowerSupplyPath = "/sys/class/power_supply" })
+
+	writeSupply(t, root, "AC", map[string]string{
+		"type": "Mains",
+	})
+
+	ns := &PowerSupply{}
+	var acc testutil.Accumulator
+	ns.gatherSupply("AC", &acc)
+
+	if len(acc.Metrics) != 0 {
+		t.Fatalf("expected no metrics for a supply with only a type file, got %d", len(acc.Metrics))
+	}
+}
+
+func TestGatherSupplyReportsBadAttrButKeepsRest(t *testing.T) {
+	root := t.TempDir()
+	sysfsPowerSupplyPath = root
+	t.Cleanup(func() { sysfsPowerSupplyPath = "/sys/class/power_supply" })
+
+	writeSupply(t, root, "BAT0", map[string]string{
+		"type":        "Battery",
+		"status":      "Discharging",
+		"cycle_count": "not-a-number",
+		"voltage_now": "12582000", // 12.582 V
+	})
+
+	ns := &PowerSupply{}
+	var acc testutil.Accumulator
+	ns.gatherSupply("BAT0", &acc)
+
+	if len(acc.Errors) != 1 {
+		t.Fatalf("expected exactly 1 error for the malformed cycle_count, got %d: %v", len(acc.Errors), acc.Errors)
+	}
+
+	acc.AssertContainsTaggedFields(t, "power_supply", map[string]interface{}{
+		"status":      "Discharging",
+		"voltage_now": 12.582,
+	}, map[string]string{
+		"name": "BAT0",
+		"type": "Battery",
+	})
+}
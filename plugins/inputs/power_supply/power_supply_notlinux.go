@@ -0,0 +1,65 @@
+//go:build !linux
+
+package power_supply
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/distatus/battery"
+	"github.com/influxdata/telegraf"
+)
+
+// Gather falls back to the distatus/battery library on platforms that
+// don't expose /sys/class/power_supply, so the plugin isn't a no-op off
+// Linux.
+func (ns *PowerSupply) Gather(acc telegraf.Accumulator) error {
+	batteries, err := battery.GetAll()
+	if errs, ok := err.(battery.Errors); ok {
+		// GetAll returns one slot per battery, left nil wherever that
+		// battery's read failed (matching a non-nil entry at the same
+		// index in errs); only report the ones that actually failed.
+		for i, e := range errs {
+			if e != nil {
+				acc.AddError(fmt.Errorf("reading battery %d: %w", i, e))
+			}
+		}
+	} else if err != nil {
+		acc.AddError(err)
+	}
+
+	for i, b := range batteries {
+		if b == nil {
+			continue
+		}
+		name := "BAT" + strconv.Itoa(i)
+		if !ns.included(name) {
+			continue
+		}
+
+		var capacity float64
+		if b.Full > 0 {
+			capacity = b.Current / b.Full * 100
+		}
+		// distatus/battery reports rate of change in watts, not amps;
+		// derive an approximate current draw so the unit matches the
+		// sysfs-backed Linux path.
+		var currentNow float64
+		if b.Voltage > 0 {
+			currentNow = b.ChargeRate / b.Voltage
+		}
+
+		tags := map[string]string{
+			"name": name,
+			"type": "Battery",
+		}
+		fields := map[string]interface{}{
+			"status":      b.State.String(),
+			"capacity":    capacity,
+			"voltage_now": b.Voltage,
+			"current_now": currentNow,
+		}
+		acc.AddFields("power_supply", fields, tags)
+	}
+	return nil
+}